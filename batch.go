@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// batchMode selects how speak_batch handles a set of jobs.
+type batchMode string
+
+const (
+	modePlaySequential     batchMode = "play_sequential"
+	modeRenderFiles        batchMode = "render_files"
+	modeRenderConcatenated batchMode = "render_concatenated"
+
+	defaultBatchConcurrency = 4
+)
+
+// BatchJob is a single unit of work within a speak_batch call.
+type BatchJob struct {
+	Name     string  `json:"name"`
+	Text     string  `json:"text"`
+	Voice    string  `json:"voice,omitempty"`
+	Rate     float64 `json:"rate,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Format   string  `json:"format,omitempty"`
+}
+
+// BatchJobResult reports the outcome of a single job.
+type BatchJobResult struct {
+	Name   string `json:"name"`
+	Status string `json:"status"` // "ok", "error", or "cancelled"
+	Path   string `json:"path,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BatchResult is the JSON result of the speak_batch tool.
+type BatchResult struct {
+	Mode string           `json:"mode"`
+	Jobs []BatchJobResult `json:"jobs"`
+	Path string           `json:"path,omitempty"` // set for render_concatenated
+}
+
+// parseBatchJobs decodes the "jobs" argument into BatchJobs, round-tripping
+// through JSON since mcp-go hands arguments back as []interface{}/map[string]interface{}.
+func parseBatchJobs(raw interface{}) ([]BatchJob, error) {
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jobs list: %w", err)
+	}
+	var jobs []BatchJob
+	if err := json.Unmarshal(encoded, &jobs); err != nil {
+		return nil, fmt.Errorf("invalid jobs list: %w", err)
+	}
+	for i, job := range jobs {
+		if strings.TrimSpace(job.Text) == "" {
+			return nil, fmt.Errorf("job %d is missing required field 'text'", i)
+		}
+		if job.Name == "" {
+			jobs[i].Name = fmt.Sprintf("job%d", i)
+		}
+	}
+	return jobs, nil
+}
+
+// handleSpeakBatch processes a list of TTS jobs under the requested mode,
+// using a worker pool to synthesize concurrently while preserving each
+// job's place in the result list.
+func handleSpeakBatch(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	allArgs := request.GetArguments()
+
+	rawJobs, ok := allArgs["jobs"]
+	if !ok {
+		return mcp.NewToolResultError("Parameter 'jobs' is required"), nil
+	}
+	jobs, err := parseBatchJobs(rawJobs)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'jobs': %v", err)), nil
+	}
+	if len(jobs) == 0 {
+		return mcp.NewToolResultError("Parameter 'jobs' must contain at least one job"), nil
+	}
+
+	mode := batchMode(strings.TrimSpace(fmt.Sprintf("%v", allArgs["mode"])))
+	switch mode {
+	case modePlaySequential, modeRenderFiles, modeRenderConcatenated:
+	default:
+		return mcp.NewToolResultError(fmt.Sprintf(
+			"Invalid parameter 'mode': %q (expected %q, %q, or %q)",
+			mode, modePlaySequential, modeRenderFiles, modeRenderConcatenated)), nil
+	}
+
+	concurrency := defaultBatchConcurrency
+	if c, ok := allArgs["concurrency"].(float64); ok && c > 0 {
+		concurrency = int(c)
+	}
+	if mode == modePlaySequential {
+		// Playback must happen in order on the single audio output device.
+		concurrency = 1
+	}
+
+	results := make([]BatchJobResult, len(jobs))
+
+	runJob := func(i int) {
+		job := jobs[i]
+		if ctx.Err() != nil {
+			results[i] = BatchJobResult{Name: job.Name, Status: "cancelled", Error: ctx.Err().Error()}
+			return
+		}
+
+		switch mode {
+		case modePlaySequential:
+			results[i] = runPlayJob(ctx, job)
+		case modeRenderFiles, modeRenderConcatenated:
+			results[i] = runRenderJob(ctx, job)
+		}
+	}
+
+	if concurrency <= 1 {
+		for i := range jobs {
+			runJob(i)
+		}
+	} else {
+		sem := make(chan struct{}, concurrency)
+		var wg sync.WaitGroup
+		for i := range jobs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				sem <- struct{}{}
+				defer func() { <-sem }()
+				runJob(i)
+			}(i)
+		}
+		wg.Wait()
+	}
+
+	result := BatchResult{Mode: string(mode), Jobs: results}
+
+	if mode == modeRenderConcatenated {
+		path, err := concatenateBatchResults(jobs, results)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to concatenate rendered segments: %v", err)), nil
+		}
+		result.Path = path
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to format result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// runPlayJob synthesizes and plays a single batch job aloud using the
+// default synthesis backend.
+func runPlayJob(ctx context.Context, job BatchJob) BatchJobResult {
+	synth, err := synthesizerFor("")
+	if err != nil {
+		return BatchJobResult{Name: job.Name, Status: "error", Error: err.Error()}
+	}
+
+	req := SynthesizeRequest{Text: job.Text, Voice: job.Voice, Rate: job.Rate, LanguageCode: job.Language}
+	audio, mimeType, err := synth.Synthesize(ctx, req)
+	if err != nil {
+		return BatchJobResult{Name: job.Name, Status: "error", Error: err.Error()}
+	}
+	if err := playAudio(ctx, audio, mimeType); err != nil {
+		return BatchJobResult{Name: job.Name, Status: "error", Error: err.Error()}
+	}
+
+	return BatchJobResult{Name: job.Name, Status: "ok"}
+}
+
+// runRenderJob synthesizes a single batch job to a content-addressed file,
+// reusing the same cache synthesize_to_file uses.
+func runRenderJob(ctx context.Context, job BatchJob) BatchJobResult {
+	format, err := parseAudioFormat(job.Format)
+	if err != nil {
+		return BatchJobResult{Name: job.Name, Status: "error", Error: err.Error()}
+	}
+
+	dir, err := synthesisCacheDir()
+	if err != nil {
+		return BatchJobResult{Name: job.Name, Status: "error", Error: err.Error()}
+	}
+	path := filepath.Join(dir, cacheKey(job.Text, job.Voice, job.Rate, format)+"."+string(format))
+
+	if err := renderToFile(ctx, job.Text, job.Voice, job.Rate, format, path, true); err != nil {
+		return BatchJobResult{Name: job.Name, Status: "error", Error: err.Error()}
+	}
+
+	return BatchJobResult{Name: job.Name, Status: "ok", Path: path}
+}
+
+// concatenateBatchResults joins the rendered segments from a
+// render_concatenated batch, in job order, into a single output file. It
+// fails if any job did not render or the jobs do not all share one format.
+func concatenateBatchResults(jobs []BatchJob, results []BatchJobResult) (string, error) {
+	var paths []string
+	format := formatAIFF
+	if len(jobs) > 0 {
+		if f, err := parseAudioFormat(jobs[0].Format); err == nil {
+			format = f
+		}
+	}
+
+	for i, result := range results {
+		if result.Status != "ok" {
+			return "", fmt.Errorf("job %q did not render successfully: %s", result.Name, result.Error)
+		}
+		if f, err := parseAudioFormat(jobs[i].Format); err != nil || f != format {
+			return "", fmt.Errorf("job %q has format %q, but all jobs must share one format to concatenate", result.Name, jobs[i].Format)
+		}
+		paths = append(paths, result.Path)
+	}
+
+	dir, err := synthesisCacheDir()
+	if err != nil {
+		return "", err
+	}
+	outPath := filepath.Join(dir, concatenationCacheKey(paths)+"."+string(format))
+
+	switch format {
+	case formatMP3:
+		if err := concatenateMP3(paths, outPath); err != nil {
+			return "", err
+		}
+	case formatAIFF:
+		if err := concatenateAIFF(paths, outPath); err != nil {
+			return "", err
+		}
+	default:
+		return "", fmt.Errorf("concatenation is only supported for aiff and mp3, not %q", format)
+	}
+
+	return outPath, nil
+}
+
+// concatenationCacheKey derives a content-addressed name for a
+// concatenated output from its ordered list of segment paths.
+func concatenationCacheKey(paths []string) string {
+	return cacheKey(strings.Join(paths, "|"), "", 0, "concat")
+}
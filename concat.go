@@ -0,0 +1,133 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// concatenateAIFF joins several AIFF files' sample data into a single AIFF
+// file. It assumes all segments share the same sample format, which holds
+// for files this server renders itself, and rewrites the FORM and SSND
+// chunk sizes while reusing the first segment's COMM chunk otherwise
+// verbatim.
+func concatenateAIFF(paths []string, outPath string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("no segments to concatenate")
+	}
+
+	var commChunk []byte
+	var sampleData bytes.Buffer
+	var totalFrames uint32
+
+	for i, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read segment %s: %w", path, err)
+		}
+		if len(data) < 12 || string(data[0:4]) != "FORM" || string(data[8:12]) != "AIFF" {
+			return fmt.Errorf("%s is not a valid AIFF file", path)
+		}
+
+		for offset := 12; offset+8 <= len(data); {
+			id := string(data[offset : offset+4])
+			size := binary.BigEndian.Uint32(data[offset+4 : offset+8])
+			bodyEnd := offset + 8 + int(size)
+			if bodyEnd > len(data) {
+				return fmt.Errorf("%s has a truncated %s chunk", path, id)
+			}
+			body := data[offset+8 : bodyEnd]
+
+			switch id {
+			case "COMM":
+				if i == 0 {
+					commChunk = append([]byte(nil), body...)
+				}
+				// COMM layout: numChannels(2) | numSampleFrames(4) | sampleSize(2) | sampleRate(10)
+				if len(body) >= 6 {
+					totalFrames += binary.BigEndian.Uint32(body[2:6])
+				}
+			case "SSND":
+				if len(body) >= 8 {
+					sampleData.Write(body[8:])
+				}
+			}
+
+			offset = bodyEnd
+			if size%2 == 1 {
+				offset++ // chunks are padded to an even length
+			}
+		}
+	}
+
+	if len(commChunk) < 6 {
+		return fmt.Errorf("malformed or missing COMM chunk in %s", paths[0])
+	}
+	// Patch the frame count now that segments are combined.
+	binary.BigEndian.PutUint32(commChunk[2:6], totalFrames)
+
+	var out bytes.Buffer
+	out.WriteString("FORM")
+	binary.Write(&out, binary.BigEndian, uint32(0)) // placeholder, patched below
+	out.WriteString("AIFF")
+
+	out.WriteString("COMM")
+	binary.Write(&out, binary.BigEndian, uint32(len(commChunk)))
+	out.Write(commChunk)
+	if len(commChunk)%2 == 1 {
+		out.WriteByte(0)
+	}
+
+	out.WriteString("SSND")
+	binary.Write(&out, binary.BigEndian, uint32(8+sampleData.Len()))
+	binary.Write(&out, binary.BigEndian, uint32(0)) // offset
+	binary.Write(&out, binary.BigEndian, uint32(0)) // blockSize
+	out.Write(sampleData.Bytes())
+	if sampleData.Len()%2 == 1 {
+		out.WriteByte(0)
+	}
+
+	result := out.Bytes()
+	binary.BigEndian.PutUint32(result[4:8], uint32(len(result)-8))
+
+	return os.WriteFile(outPath, result, 0o644)
+}
+
+// concatenateMP3 joins several MP3 files by concatenating their encoded
+// frame data, the same frame-level approach tools like mp3join use for
+// constant-bitrate streams.
+func concatenateMP3(paths []string, outPath string) error {
+	out, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outPath, err)
+	}
+	defer out.Close()
+
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read segment %s: %w", path, err)
+		}
+		// Drop ID3 tags so only the encoded frames are appended: an ID3v2
+		// header (10 bytes, syncsafe size, optional 10-byte footer) at the
+		// start and an ID3v1 tag (128 bytes starting "TAG") at the end.
+		if len(data) >= 10 && string(data[0:3]) == "ID3" {
+			tagLen := 10 + (int(data[6]&0x7f)<<21 | int(data[7]&0x7f)<<14 | int(data[8]&0x7f)<<7 | int(data[9]&0x7f))
+			if data[5]&0x10 != 0 {
+				tagLen += 10
+			}
+			if tagLen <= len(data) {
+				data = data[tagLen:]
+			}
+		}
+		if len(data) >= 128 && string(data[len(data)-128:len(data)-125]) == "TAG" {
+			data = data[:len(data)-128]
+		}
+		if _, err := out.Write(data); err != nil {
+			return fmt.Errorf("failed to append segment %s: %w", path, err)
+		}
+	}
+
+	return nil
+}
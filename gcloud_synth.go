@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	texttospeech "cloud.google.com/go/texttospeech/apiv1"
+	texttospeechpb "cloud.google.com/go/texttospeech/apiv1/texttospeechpb"
+)
+
+// gcloudSynth implements Synthesizer using the Google Cloud Text-to-Speech
+// API. Authentication follows the standard Application Default Credentials
+// lookup performed by the client library.
+type gcloudSynth struct {
+	client *texttospeech.Client
+}
+
+var (
+	gcloudSynthOnce sync.Once
+	gcloudSynthInst *gcloudSynth
+	gcloudSynthErr  error
+)
+
+// getGCloudSynth returns the process-wide gcloudSynth, creating its
+// underlying Text-to-Speech client (and gRPC connection) on first use and
+// reusing it thereafter, since this server calls it repeatedly for the
+// lifetime of the process and a new client per call would leak connections.
+func getGCloudSynth(ctx context.Context) (*gcloudSynth, error) {
+	gcloudSynthOnce.Do(func() {
+		gcloudSynthInst, gcloudSynthErr = newGCloudSynth(ctx)
+	})
+	return gcloudSynthInst, gcloudSynthErr
+}
+
+// newGCloudSynth creates a gcloudSynth backed by a new Text-to-Speech
+// client. It surfaces actionable guidance if client creation fails, which
+// usually means credentials are missing.
+func newGCloudSynth(ctx context.Context) (*gcloudSynth, error) {
+	client, err := texttospeech.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to create Google Cloud Text-to-Speech client: %w\n\n"+
+				"Please ensure:\n"+
+				"1. GOOGLE_APPLICATION_CREDENTIALS points to a valid service account key\n"+
+				"2. The Cloud Text-to-Speech API is enabled for the project\n"+
+				"3. The service account has the roles/cloudtts.user role (or equivalent)", err)
+	}
+	return &gcloudSynth{client: client}, nil
+}
+
+// Synthesize maps req onto a Google Cloud SynthesizeSpeechRequest and
+// returns the encoded audio.
+func (g *gcloudSynth) Synthesize(ctx context.Context, req SynthesizeRequest) ([]byte, string, error) {
+	languageCode := req.LanguageCode
+	if languageCode == "" {
+		languageCode = "en-US"
+	}
+
+	voice := &texttospeechpb.VoiceSelectionParams{
+		LanguageCode: languageCode,
+		Name:         req.Voice,
+	}
+	if req.Voice != "" {
+		voice.SsmlGender = gcloudGenderFor(req.Voice)
+	}
+
+	audioConfig := &texttospeechpb.AudioConfig{
+		AudioEncoding: texttospeechpb.AudioEncoding_MP3,
+	}
+	if req.Rate > 0 {
+		// say's rate is words per minute; Google's is a 0.25-4.0 multiplier
+		// of the voice's default rate, which we treat as ~175 wpm.
+		audioConfig.SpeakingRate = req.Rate / 175.0
+	}
+	if req.Pitch != 0 {
+		audioConfig.Pitch = req.Pitch
+	}
+	if req.Volume != 0 {
+		// Google's volume gain is in dB; req.Volume is on say's 0.0-1.0
+		// scale, mapped at 16dB per unit with say's default 0.6 as 0dB, so
+		// 0.0-1.0 spans -9.6dB to +6.4dB.
+		audioConfig.VolumeGainDb = (req.Volume - 0.6) * 16
+	}
+
+	input := &texttospeechpb.SynthesisInput{}
+	if req.SSML {
+		input.InputSource = &texttospeechpb.SynthesisInput_Ssml{Ssml: req.Text}
+	} else {
+		input.InputSource = &texttospeechpb.SynthesisInput_Text{Text: req.Text}
+	}
+
+	resp, err := g.client.SynthesizeSpeech(ctx, &texttospeechpb.SynthesizeSpeechRequest{
+		Input:       input,
+		Voice:       voice,
+		AudioConfig: audioConfig,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("gcloud speech synthesis failed: %w", err)
+	}
+
+	return resp.AudioContent, "audio/mpeg", nil
+}
+
+// ListVoices returns the voices available from the Google Cloud
+// Text-to-Speech API, tagged as belonging to the gcloud backend.
+func (g *gcloudSynth) ListVoices(ctx context.Context) ([]SynthesizedVoice, error) {
+	resp, err := g.client.ListVoices(ctx, &texttospeechpb.ListVoicesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve gcloud voice list: %w", err)
+	}
+
+	voices := make([]SynthesizedVoice, 0, len(resp.Voices))
+	for _, v := range resp.Voices {
+		locale := ""
+		if len(v.LanguageCodes) > 0 {
+			locale = v.LanguageCodes[0]
+		}
+		voices = append(voices, SynthesizedVoice{
+			Name:    v.Name,
+			Locale:  locale,
+			Gender:  v.SsmlGender.String(),
+			Backend: BackendGCloud,
+		})
+	}
+
+	return voices, nil
+}
+
+// gcloudGenderFor infers an SsmlVoiceGender for a voice name using the same
+// heuristic applied to system voices, so the "voice" param can be reused
+// across backends without the caller specifying gender explicitly.
+func gcloudGenderFor(name string) texttospeechpb.SsmlVoiceGender {
+	switch inferSayVoiceGender(name) {
+	case "female":
+		return texttospeechpb.SsmlVoiceGender_FEMALE
+	default:
+		return texttospeechpb.SsmlVoiceGender_SSML_VOICE_GENDER_UNSPECIFIED
+	}
+}
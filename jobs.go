@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// speechJob tracks a single in-progress speak invocation's playback
+// process, so other MCP calls can list, stop, pause, or resume it.
+type speechJob struct {
+	id          string
+	cmd         *exec.Cmd
+	textPreview string
+	voice       string
+	startedAt   time.Time
+
+	// paused is read and written from different MCP tool-call goroutines
+	// (pause_speech, resume_speech, list_active_speech); it is guarded by
+	// jobRegistry.mu rather than its own mutex since callers already hold
+	// a *speechJob obtained through the registry. Use setJobPaused/
+	// jobPaused instead of touching this field directly.
+	paused bool
+}
+
+// jobRegistry is the package-level table of active speech jobs, keyed by
+// job ID. speak populates it when playback starts and removes the entry
+// when playback finishes, so a separate MCP call can reach the process in
+// between.
+var jobRegistry = struct {
+	mu   sync.RWMutex
+	jobs map[string]*speechJob
+}{jobs: make(map[string]*speechJob)}
+
+var nextJobID uint64
+
+// newJobID returns a fresh, process-unique job identifier.
+func newJobID() string {
+	return fmt.Sprintf("job-%d", atomic.AddUint64(&nextJobID, 1))
+}
+
+// jobTextPreview truncates text for inclusion in job listings.
+func jobTextPreview(text string) string {
+	const maxLen = 60
+	if len(text) > maxLen {
+		return text[:maxLen] + "..."
+	}
+	return text
+}
+
+// registerJob adds a job to the registry.
+func registerJob(job *speechJob) {
+	jobRegistry.mu.Lock()
+	defer jobRegistry.mu.Unlock()
+	jobRegistry.jobs[job.id] = job
+}
+
+// unregisterJob removes a job from the registry once its process exits.
+func unregisterJob(id string) {
+	jobRegistry.mu.Lock()
+	defer jobRegistry.mu.Unlock()
+	delete(jobRegistry.jobs, id)
+}
+
+// lookupJob returns the job with the given ID, if still active.
+func lookupJob(id string) (*speechJob, bool) {
+	jobRegistry.mu.RLock()
+	defer jobRegistry.mu.RUnlock()
+	job, ok := jobRegistry.jobs[id]
+	return job, ok
+}
+
+// snapshotJobs returns a copy of all currently active jobs.
+func snapshotJobs() []*speechJob {
+	jobRegistry.mu.RLock()
+	defer jobRegistry.mu.RUnlock()
+	jobs := make([]*speechJob, 0, len(jobRegistry.jobs))
+	for _, job := range jobRegistry.jobs {
+		jobs = append(jobs, job)
+	}
+	return jobs
+}
+
+// signalJob delivers sig to a job's playback process.
+func signalJob(job *speechJob, sig syscall.Signal) error {
+	if job.cmd.Process == nil {
+		return fmt.Errorf("job %q has no running process", job.id)
+	}
+	return job.cmd.Process.Signal(sig)
+}
+
+// setJobPaused updates job.paused under the registry lock so concurrent
+// pause_speech/resume_speech/list_active_speech calls don't race on it.
+func setJobPaused(job *speechJob, paused bool) {
+	jobRegistry.mu.Lock()
+	defer jobRegistry.mu.Unlock()
+	job.paused = paused
+}
+
+// jobPaused reads job.paused under the registry lock; see setJobPaused.
+func jobPaused(job *speechJob) bool {
+	jobRegistry.mu.RLock()
+	defer jobRegistry.mu.RUnlock()
+	return job.paused
+}
+
+const (
+	// stopGracePeriod is how long handleStopSpeech waits after SIGTERM
+	// before escalating to SIGKILL.
+	stopGracePeriod = 2 * time.Second
+	// stopPollInterval is how often handleStopSpeech checks whether a
+	// job has exited (and thus been removed from the registry) during
+	// stopGracePeriod.
+	stopPollInterval = 100 * time.Millisecond
+)
+
+// stopJob sends SIGTERM to job's playback process, then polls the
+// registry until it exits or stopGracePeriod elapses, escalating to
+// SIGKILL if the job is still present afterward.
+func stopJob(ctx context.Context, job *speechJob) error {
+	if err := signalJob(job, syscall.SIGTERM); err != nil {
+		return err
+	}
+
+	deadline := time.NewTimer(stopGracePeriod)
+	defer deadline.Stop()
+	ticker := time.NewTicker(stopPollInterval)
+	defer ticker.Stop()
+
+	for {
+		if _, ok := lookupJob(job.id); !ok {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+			continue
+		case <-deadline.C:
+			if _, ok := lookupJob(job.id); !ok {
+				return nil
+			}
+			return signalJob(job, syscall.SIGKILL)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// ActiveJob is the JSON representation of a job returned by
+// list_active_speech.
+type ActiveJob struct {
+	ID          string `json:"id"`
+	TextPreview string `json:"text_preview"`
+	Voice       string `json:"voice,omitempty"`
+	StartedAt   string `json:"started_at"`
+	Paused      bool   `json:"paused"`
+}
+
+// handleListActiveSpeech returns every currently active speech job.
+func handleListActiveSpeech(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	jobs := snapshotJobs()
+
+	active := make([]ActiveJob, 0, len(jobs))
+	for _, job := range jobs {
+		active = append(active, ActiveJob{
+			ID:          job.id,
+			TextPreview: job.textPreview,
+			Voice:       job.voice,
+			StartedAt:   job.startedAt.Format(time.RFC3339),
+			Paused:      jobPaused(job),
+		})
+	}
+
+	jsonData, err := json.MarshalIndent(struct {
+		Jobs []ActiveJob `json:"jobs"`
+	}{Jobs: active}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to format job list: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// handleStopSpeech sends SIGTERM to one job, or every active job when id
+// is "all", falling back to SIGKILL if a job ignores the initial signal.
+func handleStopSpeech(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'id': %v", err)), nil
+	}
+
+	var targets []*speechJob
+	if id == "all" {
+		targets = snapshotJobs()
+	} else {
+		job, ok := lookupJob(id)
+		if !ok {
+			return mcp.NewToolResultError(fmt.Sprintf("No active speech job with id %q", id)), nil
+		}
+		targets = []*speechJob{job}
+	}
+
+	var stopped []string
+	var failures []string
+	for _, job := range targets {
+		if err := stopJob(ctx, job); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", job.id, err))
+			continue
+		}
+		stopped = append(stopped, job.id)
+	}
+
+	if len(failures) > 0 {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to stop %d job(s): %s", len(failures), strings.Join(failures, ", "))), nil
+	}
+
+	return mcp.NewToolResultText(fmt.Sprintf("Stopped %d job(s): %s", len(stopped), strings.Join(stopped, ", "))), nil
+}
+
+// handlePauseSpeech sends SIGSTOP to a job's playback process.
+func handlePauseSpeech(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'id': %v", err)), nil
+	}
+
+	job, ok := lookupJob(id)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("No active speech job with id %q", id)), nil
+	}
+	if err := signalJob(job, syscall.SIGSTOP); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to pause job %q: %v", id, err)), nil
+	}
+	setJobPaused(job, true)
+
+	return mcp.NewToolResultText(fmt.Sprintf("Paused job %s", id)), nil
+}
+
+// handleResumeSpeech sends SIGCONT to a previously paused job.
+func handleResumeSpeech(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	id, err := request.RequireString("id")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'id': %v", err)), nil
+	}
+
+	job, ok := lookupJob(id)
+	if !ok {
+		return mcp.NewToolResultError(fmt.Sprintf("No active speech job with id %q", id)), nil
+	}
+	if err := signalJob(job, syscall.SIGCONT); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to resume job %q: %v", id, err)), nil
+	}
+	setJobPaused(job, false)
+
+	return mcp.NewToolResultText(fmt.Sprintf("Resumed job %s", id)), nil
+}
@@ -1,15 +1,18 @@
-// Package main implements an MCP server that provides text-to-speech functionality
-// using macOS's built-in speech synthesis via /usr/bin/say.
+// Package main implements an MCP server that provides text-to-speech and
+// speech-to-text functionality, via macOS's built-in /usr/bin/say and
+// pluggable cloud/local backends.
 package main
 
 import (
 	"context"
 	"encoding/json"
 	"errors"
+	"flag"
 	"fmt"
+	"os"
 	"os/exec"
-	"regexp"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
 	"github.com/mark3labs/mcp-go/server"
@@ -28,6 +31,17 @@ Permission denied. Please ensure:
 
 // main initializes and starts the MCP server with the speak tool.
 func main() {
+	backendFlag := flag.String("backend", "", fmt.Sprintf(
+		"speech synthesis backend to use (%q or %q); defaults to %s env var, then %q",
+		BackendSay, BackendGCloud, backendEnvVar, defaultBackend))
+	transcribeBackendFlag := flag.String("transcribe-backend", "", fmt.Sprintf(
+		"speech recognition backend to use (%q or %q); defaults to %s env var, then %q",
+		RecognizerWhisper, RecognizerGCloud, recognizerBackendEnvVar, defaultRecognizerBackend))
+	flag.Parse()
+
+	configuredBackend = resolveDefaultBackend(*backendFlag)
+	configuredRecognizerBackend = resolveDefaultRecognizerBackend(*transcribeBackendFlag)
+
 	// Create MCP server
 	s := server.NewMCPServer(
 		"kj-speech-mcp",
@@ -48,6 +62,27 @@ func main() {
 		mcp.WithNumber("rate",
 			mcp.Description("Speech rate in words per minute (optional, uses system default if not specified)"),
 		),
+		mcp.WithString("backend",
+			mcp.Description(fmt.Sprintf("Synthesis backend to use for this call (%q or %q), overriding the server default", BackendSay, BackendGCloud)),
+		),
+		mcp.WithString("language_code",
+			mcp.Description("BCP-47 language code for synthesis, e.g. \"en-US\" (optional, only honored by backends that support it)"),
+		),
+		mcp.WithNumber("pitch",
+			mcp.Description("Pitch adjustment in semitones (optional, only honored by backends that support it)"),
+		),
+		mcp.WithBoolean("ssml",
+			mcp.Description("Treat 'text' as SSML instead of plain text (optional; auto-detected when text starts with <speak>)"),
+		),
+		mcp.WithNumber("volume",
+			mcp.Description("Volume as a 0.0-1.0 fraction (optional, only honored by backends that support it; the say backend ignores it)"),
+		),
+		mcp.WithString("emphasis",
+			mcp.Description("Emphasis to apply to the whole utterance: \"strong\", \"moderate\", or \"reduced\" (optional, ignored when ssml is used)"),
+		),
+		mcp.WithBoolean("wait",
+			mcp.Description("Wait for playback to finish before returning (optional, defaults to true; set false to fire-and-forget and control the job with stop_speech/pause_speech/resume_speech)"),
+		),
 	)
 
 	// Add speak tool handler
@@ -61,14 +96,116 @@ func main() {
 	// Add list_voices tool handler
 	s.AddTool(listVoicesTool, handleVoices)
 
+	// Define the synthesize_to_file tool
+	synthesizeToFileTool := mcp.NewTool("synthesize_to_file",
+		mcp.WithDescription("Renders text to an audio file (AIFF/WAV/CAF/MP3) instead of playing it aloud"),
+		mcp.WithString("text",
+			mcp.Required(),
+			mcp.Description("The text to synthesize"),
+		),
+		mcp.WithString("voice",
+			mcp.Description("Voice to use for speech synthesis (optional, uses system default if not specified)"),
+		),
+		mcp.WithNumber("rate",
+			mcp.Description("Speech rate in words per minute (optional, uses system default if not specified)"),
+		),
+		mcp.WithString("format",
+			mcp.Description("Output audio format: \"aiff\", \"wav\", \"caf\", or \"mp3\" (optional, defaults to aiff)"),
+		),
+		mcp.WithString("output_path",
+			mcp.Description("Destination file path (optional; defaults to a content-addressed path under the cache directory, skipping re-synthesis if it already exists)"),
+		),
+	)
+
+	// Add synthesize_to_file tool handler
+	s.AddTool(synthesizeToFileTool, handleSynthesizeToFile)
+
+	// Define the speak_batch tool
+	speakBatchTool := mcp.NewTool("speak_batch",
+		mcp.WithDescription("Processes multiple text-to-speech jobs, playing them in sequence or rendering them to files (optionally concatenated)"),
+		mcp.WithArray("jobs",
+			mcp.Required(),
+			mcp.Description("Jobs to process, each with name, text, and optional voice/rate/language/format"),
+		),
+		mcp.WithString("mode",
+			mcp.Required(),
+			mcp.Description("One of \"play_sequential\", \"render_files\", or \"render_concatenated\""),
+		),
+		mcp.WithNumber("concurrency",
+			mcp.Description("Number of jobs to synthesize in parallel (optional, defaults to 4; ignored for play_sequential)"),
+		),
+	)
+
+	// Add speak_batch tool handler
+	s.AddTool(speakBatchTool, handleSpeakBatch)
+
+	// Define the job control tools for speech started with wait=false
+	listActiveTool := mcp.NewTool("list_active_speech",
+		mcp.WithDescription("Lists in-progress speech jobs started with speak"),
+	)
+	s.AddTool(listActiveTool, handleListActiveSpeech)
+
+	stopTool := mcp.NewTool("stop_speech",
+		mcp.WithDescription("Stops one or all in-progress speech jobs"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Job ID to stop, or \"all\" to stop every active job"),
+		),
+	)
+	s.AddTool(stopTool, handleStopSpeech)
+
+	pauseTool := mcp.NewTool("pause_speech",
+		mcp.WithDescription("Pauses an in-progress speech job"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Job ID to pause"),
+		),
+	)
+	s.AddTool(pauseTool, handlePauseSpeech)
+
+	resumeTool := mcp.NewTool("resume_speech",
+		mcp.WithDescription("Resumes a previously paused speech job"),
+		mcp.WithString("id",
+			mcp.Required(),
+			mcp.Description("Job ID to resume"),
+		),
+	)
+	s.AddTool(resumeTool, handleResumeSpeech)
+
+	// Define the transcribe tool
+	transcribeTool := mcp.NewTool("transcribe",
+		mcp.WithDescription("Transcribes speech audio to text, as a companion to speak"),
+		mcp.WithString("audio_path",
+			mcp.Description("Path to a local audio file to transcribe (either this or audio_base64 is required)"),
+		),
+		mcp.WithString("audio_base64",
+			mcp.Description("Base64-encoded audio to transcribe (either this or audio_path is required)"),
+		),
+		mcp.WithString("mime_type",
+			mcp.Description("MIME type of audio_base64, e.g. \"audio/wav\" (optional, ignored for audio_path)"),
+		),
+		mcp.WithString("language",
+			mcp.Description("BCP-47 language code of the audio, e.g. \"en-US\" (optional; if omitted, gcloud assumes en-US and whisper uses its own default)"),
+		),
+		mcp.WithString("model",
+			mcp.Description("Recognition model to use: a whisper.cpp ggml model file path or a Cloud Speech model name (optional)"),
+		),
+		mcp.WithString("backend",
+			mcp.Description(fmt.Sprintf("Recognition backend to use for this call (%q or %q), overriding the server default", RecognizerWhisper, RecognizerGCloud)),
+		),
+	)
+
+	// Add transcribe tool handler
+	s.AddTool(transcribeTool, handleTranscribe)
+
 	// Serve via stdio
 	if err := server.ServeStdio(s); err != nil {
 		fmt.Printf("Server error: %v\n", err)
 	}
 }
 
-// handleSpeak processes text-to-speech requests via the macOS say command.
-// It supports optional voice selection and speech rate control.
+// handleSpeak processes text-to-speech requests against the configured
+// synthesis backend (or a per-call override) and plays back the result.
 func handleSpeak(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
 	// Extract text parameter using type-safe helper
 	text, err := request.RequireString("text")
@@ -82,126 +219,213 @@ func handleSpeak(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToo
 		return mcp.NewToolResultError("Parameter 'text' cannot be empty"), nil
 	}
 
-	// Build command arguments
-	var args []string
-
 	// Get all arguments for optional parameters
 	allArgs := request.GetArguments()
 
-	// Add optional voice parameter
-	if voice, ok := allArgs["voice"].(string); ok && voice != "" {
-		args = append(args, "-v", voice)
-	}
-
-	// Add optional rate parameter
-	if rate, ok := allArgs["rate"].(float64); ok && rate > 0 {
+	var rate float64
+	if r, ok := allArgs["rate"].(float64); ok && r > 0 {
 		// say command typically accepts 90-500 words per minute
-		if rate < 90 || rate > 500 {
+		if r < 90 || r > 500 {
 			return mcp.NewToolResultError(
-				fmt.Sprintf("Rate %.0f is outside acceptable range (90-500 words per minute)", rate),
+				fmt.Sprintf("Rate %.0f is outside acceptable range (90-500 words per minute)", r),
 			), nil
 		}
-		args = append(args, "-r", fmt.Sprintf("%.0f", rate))
+		rate = r
 	}
 
-	// Add the text to speak
-	args = append(args, text)
-
-	// Execute say command
-	cmd := exec.CommandContext(ctx, sayCommand, args...)
-	output, err := cmd.CombinedOutput()
+	var backendName string
+	if b, ok := allArgs["backend"].(string); ok {
+		backendName = b
+	}
 
+	synth, err := synthesizerFor(backendName)
 	if err != nil {
-		// Check if context was cancelled
-		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return mcp.NewToolResultError(
-				fmt.Sprintf("Speech synthesis cancelled: %v", ctx.Err()),
-			), nil
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'backend': %v", err)), nil
+	}
+
+	req := SynthesizeRequest{Text: text, Rate: rate}
+	if voice, ok := allArgs["voice"].(string); ok {
+		req.Voice = voice
+	}
+	if languageCode, ok := allArgs["language_code"].(string); ok {
+		req.LanguageCode = languageCode
+	}
+	if pitch, ok := allArgs["pitch"].(float64); ok {
+		req.Pitch = pitch
+	}
+	if volume, ok := allArgs["volume"].(float64); ok {
+		req.Volume = volume
+	}
+
+	useSSML, _ := allArgs["ssml"].(bool)
+	useSSML = useSSML || looksLikeSSML(text)
+
+	if useSSML {
+		req.SSML = true
+	} else if emphasis, ok := allArgs["emphasis"].(string); ok && emphasis != "" {
+		// Shorthand: wrap the plain-text request in an <emphasis> element so
+		// a caller can ask for emphasis without writing full SSML. The
+		// wrapped document is validated here, then handled like any other
+		// SSML request below.
+		wrapped := fmt.Sprintf("<speak><emphasis level=%q>%s</emphasis></speak>", emphasis, xmlEscape(text))
+		if _, _, err := translateSSML(wrapped); err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'emphasis': %v", err)), nil
 		}
+		req.Text = wrapped
+		req.SSML = true
+	}
+
+	_, usingGCloud := synth.(*gcloudSynth)
 
-		// Handle permission and execution errors
-		errMsg := fmt.Sprintf("Failed to execute speech synthesis: %v", err)
-		if len(output) > 0 {
-			errMsg = fmt.Sprintf("%s\nOutput: %s", errMsg, string(output))
+	if req.SSML && !usingGCloud {
+		translated, voice, err := translateSSML(req.Text)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'text': %v", err)), nil
+		}
+		req.Text = translated
+		req.SSML = false
+		if voice != "" && req.Voice == "" {
+			req.Voice = voice
 		}
+	}
 
-		// Check for common permission issues
-		if strings.Contains(err.Error(), "permission denied") {
-			errMsg += permissionGuidance
+	audio, mimeType, err := synth.Synthesize(ctx, req)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return mcp.NewToolResultError(fmt.Sprintf("Speech synthesis cancelled: %v", ctx.Err())), nil
 		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to execute speech synthesis: %v", err)), nil
+	}
+
+	cmd, tmpPath, err := startPlayback(audio, mimeType)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Synthesized speech but failed to play it: %v", err)), nil
+	}
 
-		return mcp.NewToolResultError(errMsg), nil
+	job := &speechJob{id: newJobID(), cmd: cmd, textPreview: jobTextPreview(text), voice: req.Voice, startedAt: time.Now()}
+	registerJob(job)
+
+	wait := true
+	if w, ok := allArgs["wait"].(bool); ok {
+		wait = w
 	}
 
-	return mcp.NewToolResultText(fmt.Sprintf("Successfully spoke: %s", text)), nil
-}
+	if !wait {
+		go func() {
+			defer unregisterJob(job.id)
+			defer os.Remove(tmpPath)
+			cmd.Wait()
+		}()
+		return mcp.NewToolResultText(fmt.Sprintf("Speaking (job %s): %s", job.id, text)), nil
+	}
+
+	defer unregisterJob(job.id)
+	defer os.Remove(tmpPath)
+	if err := waitForPlayback(cmd); err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Synthesized speech but failed to play it: %v", err)), nil
+	}
 
-// Voice represents a single text-to-speech voice with its metadata.
-type Voice struct {
-	Name        string `json:"name"`
-	Locale      string `json:"locale"`
-	Description string `json:"description"`
+	return mcp.NewToolResultText(fmt.Sprintf("Successfully spoke (job %s): %s", job.id, text)), nil
 }
 
-// VoicesResponse contains the list of available voices.
-type VoicesResponse struct {
-	Voices []Voice `json:"voices"`
+// playAudio writes audio to a temporary file and plays it to completion
+// with afplay, macOS's command-line audio player. It works for any audio
+// format afplay supports (AIFF, WAV, MP3, CAF), which covers every
+// backend's output.
+func playAudio(ctx context.Context, audio []byte, mimeType string) error {
+	cmd, tmpPath, err := startPlayback(audio, mimeType)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	return waitForPlayback(cmd)
 }
 
-// handleVoices retrieves and returns a list of all available text-to-speech voices.
-func handleVoices(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	// Execute say -v '?' to get list of voices
-	cmd := exec.CommandContext(ctx, sayCommand, "-v", "?")
-	output, err := cmd.CombinedOutput()
+// startPlayback writes audio to a temporary file and starts (but does not
+// wait for) afplay against it. The returned *exec.Cmd is not tied to any
+// request context: playback must be able to outlive the MCP call that
+// started it (see handleSpeak's "wait" parameter and the job control
+// tools), so cancellation goes through stop_speech rather than ctx.
+func startPlayback(audio []byte, mimeType string) (cmd *exec.Cmd, tmpPath string, err error) {
+	ext := ".aiff"
+	if mimeType == "audio/mpeg" {
+		ext = ".mp3"
+	}
 
+	tmpFile, err := os.CreateTemp("", "kj-speech-*"+ext)
 	if err != nil {
-		// Check if context was cancelled
-		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
-			return mcp.NewToolResultError(
-				fmt.Sprintf("Voice listing cancelled: %v", ctx.Err()),
-			), nil
-		}
+		return nil, "", fmt.Errorf("failed to create temporary audio file: %w", err)
+	}
+	tmpPath = tmpFile.Name()
 
-		errMsg := fmt.Sprintf("Failed to retrieve voice list: %v", err)
-		if len(output) > 0 {
-			errMsg = fmt.Sprintf("%s\nOutput: %s", errMsg, string(output))
-		}
-		return mcp.NewToolResultError(errMsg), nil
+	if _, err := tmpFile.Write(audio); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return nil, "", fmt.Errorf("failed to write temporary audio file: %w", err)
+	}
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return nil, "", fmt.Errorf("failed to finalize temporary audio file: %w", err)
 	}
 
-	// Parse the output
-	// Format: "VoiceName    locale    # description"
-	// Example: "Albert              en_US    # Hello! My name is Albert."
-	lines := strings.Split(string(output), "\n")
-	voices := make([]Voice, 0, len(lines))
+	cmd = exec.Command("/usr/bin/afplay", tmpPath)
+	if err := cmd.Start(); err != nil {
+		os.Remove(tmpPath)
+		return nil, "", fmt.Errorf("failed to start audio playback: %w", err)
+	}
 
-	// Regex to parse voice lines: name, locale, and description
-	// Pattern: voice name (any chars), whitespace, locale, whitespace, #, description
-	voicePattern := regexp.MustCompile(`^(.+?)\s+(\S+)\s+#\s*(.*)$`)
+	return cmd, tmpPath, nil
+}
 
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+// waitForPlayback waits for a started afplay command and translates a
+// failure into an actionable error, following the same permission-guidance
+// convention as the rest of the server.
+func waitForPlayback(cmd *exec.Cmd) error {
+	if err := cmd.Wait(); err != nil {
+		errMsg := fmt.Sprintf("failed to play audio: %v", err)
+		if strings.Contains(err.Error(), "permission denied") {
+			errMsg += permissionGuidance
 		}
+		return errors.New(errMsg)
+	}
+
+	return nil
+}
+
+// VoicesResponse contains the merged, backend-tagged list of available
+// voices.
+type VoicesResponse struct {
+	Voices []SynthesizedVoice `json:"voices"`
+}
+
+// handleVoices retrieves and returns a merged list of available
+// text-to-speech voices from every backend that can be reached. The say
+// backend is always queried; other backends (e.g. gcloud) are included
+// only when configured and reachable, so a missing cloud credential
+// doesn't fail the whole call.
+func handleVoices(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	var voices []SynthesizedVoice
 
-		matches := voicePattern.FindStringSubmatch(line)
-		if len(matches) == 4 {
-			voices = append(voices, Voice{
-				Name:        strings.TrimSpace(matches[1]),
-				Locale:      strings.TrimSpace(matches[2]),
-				Description: strings.TrimSpace(matches[3]),
-			})
+	say := saySynth{}
+	sayVoices, err := say.ListVoices(ctx)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return mcp.NewToolResultError(fmt.Sprintf("Voice listing cancelled: %v", ctx.Err())), nil
 		}
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to retrieve voice list: %v", err)), nil
 	}
+	voices = append(voices, sayVoices...)
 
-	// Create response
-	response := VoicesResponse{
-		Voices: voices,
+	if Backend(configuredBackend) == BackendGCloud {
+		if gcloud, err := getGCloudSynth(ctx); err == nil {
+			if gcloudVoices, err := gcloud.ListVoices(ctx); err == nil {
+				voices = append(voices, gcloudVoices...)
+			}
+		}
 	}
 
-	// Convert to JSON
-	jsonData, err := json.MarshalIndent(response, "", "  ")
+	jsonData, err := json.MarshalIndent(VoicesResponse{Voices: voices}, "", "  ")
 	if err != nil {
 		return mcp.NewToolResultError(fmt.Sprintf("Failed to format voice list: %v", err)), nil
 	}
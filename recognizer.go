@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// RecognizerBackend identifies a speech-to-text backend.
+type RecognizerBackend string
+
+const (
+	// RecognizerWhisper shells out to a local whisper.cpp/whisper binary.
+	RecognizerWhisper RecognizerBackend = "whisper"
+	// RecognizerGCloud uses the Google Cloud Speech-to-Text API.
+	RecognizerGCloud RecognizerBackend = "gcloud"
+
+	defaultRecognizerBackend = RecognizerWhisper
+
+	// recognizerBackendEnvVar overrides the default transcription backend.
+	recognizerBackendEnvVar = "KJ_TRANSCRIBE_BACKEND"
+)
+
+// TranscribeRequest carries the parameters needed to transcribe audio.
+type TranscribeRequest struct {
+	Audio    []byte
+	MimeType string
+	Language string
+	Model    string
+}
+
+// Segment is a portion of a transcript attributed to a time range, in
+// seconds from the start of the audio.
+type Segment struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Text  string  `json:"text"`
+}
+
+// TranscriptionResult is the outcome of a Recognizer.Transcribe call.
+type TranscriptionResult struct {
+	Transcript   string    `json:"transcript"`
+	Confidence   float64   `json:"confidence,omitempty"`
+	Alternatives []string  `json:"alternatives,omitempty"`
+	Segments     []Segment `json:"segments,omitempty"`
+}
+
+// Recognizer converts audio to text. Implementations are free to shell out
+// to a local binary or call a cloud API; handleTranscribe depends only on
+// this interface.
+type Recognizer interface {
+	Transcribe(ctx context.Context, req TranscribeRequest) (*TranscriptionResult, error)
+}
+
+// configuredRecognizerBackend is the default transcription backend
+// selected at startup via the --transcribe-backend flag or the
+// KJ_TRANSCRIBE_BACKEND environment variable.
+var configuredRecognizerBackend = string(defaultRecognizerBackend)
+
+// resolveDefaultRecognizerBackend determines the process-wide default
+// transcription backend, preferring an explicit flag value over the
+// environment variable.
+func resolveDefaultRecognizerBackend(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if envValue := os.Getenv(recognizerBackendEnvVar); envValue != "" {
+		return envValue
+	}
+	return string(defaultRecognizerBackend)
+}
+
+// recognizerFor resolves a backend name to a Recognizer, falling back to
+// the process-wide default backend when name is empty.
+func recognizerFor(name string) (Recognizer, error) {
+	if name == "" {
+		name = configuredRecognizerBackend
+	}
+
+	switch RecognizerBackend(name) {
+	case RecognizerWhisper:
+		return whisperRecognizer{}, nil
+	case RecognizerGCloud:
+		return getGCloudRecognizer(context.Background())
+	default:
+		return nil, fmt.Errorf("unknown transcription backend %q (expected %q or %q)", name, RecognizerWhisper, RecognizerGCloud)
+	}
+}
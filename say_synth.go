@@ -0,0 +1,125 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// saySynth implements Synthesizer using macOS's built-in /usr/bin/say
+// command. It is the default backend and preserves the server's original
+// behavior.
+type saySynth struct{}
+
+// Synthesize renders req.Text to AIFF audio by invoking say with -o against
+// a temporary file, then reads the result back into memory.
+func (saySynth) Synthesize(ctx context.Context, req SynthesizeRequest) ([]byte, string, error) {
+	tmpFile, err := os.CreateTemp("", "kj-speech-*.aiff")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create temporary audio file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	var args []string
+	if req.Voice != "" {
+		args = append(args, "-v", req.Voice)
+	}
+	if req.Rate > 0 {
+		args = append(args, "-r", fmt.Sprintf("%.0f", req.Rate))
+	}
+	args = append(args, "-o", tmpPath, req.Text)
+
+	cmd := exec.CommandContext(ctx, sayCommand, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, "", fmt.Errorf("speech synthesis cancelled: %w", ctx.Err())
+		}
+
+		errMsg := fmt.Sprintf("failed to execute speech synthesis: %v", err)
+		if len(output) > 0 {
+			errMsg = fmt.Sprintf("%s\noutput: %s", errMsg, string(output))
+		}
+		if strings.Contains(err.Error(), "permission denied") {
+			errMsg += permissionGuidance
+		}
+		return nil, "", errors.New(errMsg)
+	}
+
+	audio, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read synthesized audio: %w", err)
+	}
+
+	return audio, "audio/aiff", nil
+}
+
+// sayVoicePattern parses a line of `say -v ?` output: voice name, locale,
+// and description. Example: "Albert    en_US    # Hello! My name is Albert."
+var sayVoicePattern = regexp.MustCompile(`^(.+?)\s+(\S+)\s+#\s*(.*)$`)
+
+// ListVoices runs `say -v ?` and parses the resulting voice list.
+func (saySynth) ListVoices(ctx context.Context) ([]SynthesizedVoice, error) {
+	cmd := exec.CommandContext(ctx, sayCommand, "-v", "?")
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.Canceled) || errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("voice listing cancelled: %w", ctx.Err())
+		}
+
+		errMsg := fmt.Sprintf("failed to retrieve voice list: %v", err)
+		if len(output) > 0 {
+			errMsg = fmt.Sprintf("%s\noutput: %s", errMsg, string(output))
+		}
+		return nil, errors.New(errMsg)
+	}
+
+	lines := strings.Split(string(output), "\n")
+	voices := make([]SynthesizedVoice, 0, len(lines))
+
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		matches := sayVoicePattern.FindStringSubmatch(line)
+		if len(matches) == 4 {
+			name := strings.TrimSpace(matches[1])
+			voices = append(voices, SynthesizedVoice{
+				Name:        name,
+				Locale:      strings.TrimSpace(matches[2]),
+				Description: strings.TrimSpace(matches[3]),
+				Gender:      inferSayVoiceGender(name),
+				Backend:     BackendSay,
+			})
+		}
+	}
+
+	return voices, nil
+}
+
+// sayFemaleVoices lists well-known female system voice names. macOS's say
+// command does not report gender, so callers that need an
+// SsmlVoiceGender-style hint (e.g. the gcloud backend mapping a "voice"
+// param back onto a system voice) fall back to this allowlist.
+var sayFemaleVoices = map[string]bool{
+	"Allison": true, "Ava": true, "Kathy": true, "Samantha": true,
+	"Susan": true, "Victoria": true, "Vicki": true, "Karen": true,
+	"Moira": true, "Tessa": true, "Veena": true, "Fiona": true,
+}
+
+// inferSayVoiceGender guesses a voice's gender from its name, since the say
+// command does not report one. It returns "" when unknown.
+func inferSayVoiceGender(name string) string {
+	if sayFemaleVoices[name] {
+		return "female"
+	}
+	return ""
+}
@@ -0,0 +1,384 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// defaultSSMLRateWPM is the words-per-minute say uses for a voice's default
+// rate, used as the baseline for SSML's relative rate keywords/percentages.
+const defaultSSMLRateWPM = 175
+
+// xmlEscape escapes text for safe inclusion as XML character data, used
+// when wrapping plain-text shorthand parameters (e.g. emphasis) in a
+// synthetic SSML document before translation.
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// looksLikeSSML reports whether text appears to be an SSML document, by
+// checking for a leading <speak> root. It lets callers opt into SSML
+// handling without having to pass ssml=true explicitly.
+func looksLikeSSML(text string) bool {
+	trimmed := strings.TrimSpace(text)
+	return strings.HasPrefix(strings.ToLower(trimmed), "<speak")
+}
+
+// ssmlRateKeywords maps SSML's relative rate keywords onto words-per-minute.
+var ssmlRateKeywords = map[string]int{
+	"x-slow": 80, "slow": 120, "medium": defaultSSMLRateWPM, "fast": 250, "x-fast": 350,
+}
+
+// ssmlPitchKeywords maps SSML's relative pitch keywords onto semitone
+// offsets passed to say's [[pbas ...]] command.
+var ssmlPitchKeywords = map[string]int{
+	"x-low": -12, "low": -6, "medium": 0, "high": 6, "x-high": 12,
+}
+
+// ssmlVolumeKeywords maps SSML's relative volume keywords onto say's
+// [[volm ...]] scale (0.0-1.0).
+var ssmlVolumeKeywords = map[string]float64{
+	"silent": 0.0, "x-soft": 0.2, "soft": 0.4, "medium": 0.6, "loud": 0.8, "x-loud": 1.0,
+}
+
+// ssmlSupportedElements is the documented subset of SSML this server
+// translates. Any other element name is rejected with a clear error rather
+// than silently ignored, since say has no general-purpose way to honor it.
+var ssmlSupportedElements = map[string]bool{
+	"speak": true, "break": true, "prosody": true, "emphasis": true,
+	"say-as": true, "sub": true, "voice": true,
+}
+
+// translateSSML converts a documented subset of SSML (break, prosody,
+// emphasis, say-as, sub, voice) into text containing say's [[...]] embedded
+// speech commands. It returns the translated text and, if the document
+// contains a single top-level <voice name="..."> element, the voice name
+// to use (say cannot switch voices mid-utterance, so nested or multiple
+// <voice> elements are rejected).
+func translateSSML(doc string) (text string, voice string, err error) {
+	t := &ssmlTranslator{decoder: xml.NewDecoder(strings.NewReader(doc))}
+	if err := t.run(); err != nil {
+		return "", "", err
+	}
+	return t.out.String(), t.voice, nil
+}
+
+// ssmlTranslator walks an SSML document with a token-based XML decoder,
+// emitting say embedded commands as it enters and leaves elements that
+// adjust speech parameters.
+type ssmlTranslator struct {
+	decoder *xml.Decoder
+	out     strings.Builder
+
+	voice      string
+	voiceDepth int // nesting depth at which the top-level <voice> was seen, 0 if none
+
+	suppressDepth int // nesting depth of an open <sub>, 0 if not inside one
+	charDepth     int // nesting depth of an open literal-mode <say-as>, 0 if none
+
+	depth      int
+	rateStack  []int
+	pitchStack []int
+	volStack   []float64
+}
+
+func (t *ssmlTranslator) run() error {
+	for {
+		tok, err := t.decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid SSML: %w", err)
+		}
+
+		switch el := tok.(type) {
+		case xml.StartElement:
+			if !ssmlSupportedElements[el.Name.Local] {
+				return fmt.Errorf("unsupported SSML element <%s>; supported elements are break, prosody, emphasis, say-as, sub, voice", el.Name.Local)
+			}
+			if t.suppressDepth != 0 {
+				return fmt.Errorf("<sub> must not contain nested elements")
+			}
+			if err := t.startElement(el); err != nil {
+				return err
+			}
+			t.depth++
+
+		case xml.EndElement:
+			t.depth--
+			if err := t.endElement(el); err != nil {
+				return err
+			}
+
+		case xml.CharData:
+			if t.suppressDepth == 0 {
+				t.out.Write(el)
+			}
+		}
+	}
+}
+
+func attr(el xml.StartElement, name string) (string, bool) {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+func (t *ssmlTranslator) startElement(el xml.StartElement) error {
+	switch el.Name.Local {
+	case "speak":
+		// Root element carries no speech commands of its own.
+
+	case "break":
+		ms, err := parseBreakTime(el)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(&t.out, "[[slnc %d]]", ms)
+
+	case "prosody":
+		curRate, curPitch, curVol := t.currentRate(), t.currentPitch(), t.currentVolume()
+		t.rateStack = append(t.rateStack, curRate)
+		t.pitchStack = append(t.pitchStack, curPitch)
+		t.volStack = append(t.volStack, curVol)
+
+		if v, ok := attr(el, "rate"); ok {
+			rate, err := parseSSMLRate(v, curRate)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&t.out, "[[rate %d]]", rate)
+		}
+		if v, ok := attr(el, "pitch"); ok {
+			pitch, err := parseSSMLPitch(v, curPitch)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&t.out, "[[pbas %+d]]", pitch)
+		}
+		if v, ok := attr(el, "volume"); ok {
+			vol, err := parseSSMLVolume(v, curVol)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&t.out, "[[volm %.2f]]", vol)
+		}
+
+	case "emphasis":
+		level, _ := attr(el, "level")
+		curPitch := t.currentPitch()
+		t.pitchStack = append(t.pitchStack, curPitch)
+		t.rateStack = append(t.rateStack, t.currentRate())
+		t.volStack = append(t.volStack, t.currentVolume())
+
+		switch level {
+		case "strong":
+			t.out.WriteString("[[emph +]]")
+		case "reduced":
+			t.out.WriteString("[[emph -]]")
+		case "moderate", "":
+			// No embedded command needed; default emphasis.
+		default:
+			return fmt.Errorf("unsupported emphasis level %q", level)
+		}
+
+	case "say-as":
+		interpretAs, _ := attr(el, "interpret-as")
+		switch interpretAs {
+		case "characters", "spell-out", "digits":
+			t.out.WriteString("[[char LTRL]]")
+			t.charDepth = t.depth + 1
+		default:
+			return fmt.Errorf("unsupported say-as interpret-as %q; supported values are characters, digits, spell-out", interpretAs)
+		}
+
+	case "sub":
+		alias, ok := attr(el, "alias")
+		if !ok {
+			return fmt.Errorf("<sub> requires an alias attribute")
+		}
+		t.out.WriteString(alias)
+		// Suppress the original text content until the matching end
+		// element; see the CharData case in run() and endElement's "sub"
+		// case.
+		t.suppressDepth = t.depth + 1
+
+	case "voice":
+		name, ok := attr(el, "name")
+		if !ok {
+			return fmt.Errorf("<voice> requires a name attribute")
+		}
+		if t.voice != "" {
+			return fmt.Errorf("only a single top-level <voice> element is supported; say cannot switch voices mid-utterance")
+		}
+		t.voice = name
+		t.voiceDepth = t.depth + 1
+	}
+
+	return nil
+}
+
+func (t *ssmlTranslator) endElement(el xml.EndElement) error {
+	switch el.Name.Local {
+	case "prosody":
+		rate, pitch, vol := t.pop()
+		fmt.Fprintf(&t.out, "[[rate %d]][[pbas %+d]][[volm %.2f]]", rate, pitch, vol)
+
+	case "emphasis":
+		_, _, _ = t.pop()
+		t.out.WriteString("[[emph -]][[emph +]]") // reset to baseline emphasis
+
+	case "say-as":
+		if t.charDepth != 0 {
+			t.out.WriteString("[[char NORM]]")
+			t.charDepth = 0
+		}
+
+	case "sub":
+		t.suppressDepth = 0
+
+	case "voice":
+		if t.depth+1 != t.voiceDepth {
+			return fmt.Errorf("nested <voice> elements are not supported")
+		}
+	}
+	return nil
+}
+
+func (t *ssmlTranslator) pop() (rate, pitch int, vol float64) {
+	n := len(t.rateStack) - 1
+	rate, pitch, vol = t.rateStack[n], t.pitchStack[n], t.volStack[n]
+	t.rateStack, t.pitchStack, t.volStack = t.rateStack[:n], t.pitchStack[:n], t.volStack[:n]
+	return
+}
+
+func (t *ssmlTranslator) currentRate() int {
+	if len(t.rateStack) == 0 {
+		return defaultSSMLRateWPM
+	}
+	return t.rateStack[len(t.rateStack)-1]
+}
+
+func (t *ssmlTranslator) currentPitch() int {
+	if len(t.pitchStack) == 0 {
+		return 0
+	}
+	return t.pitchStack[len(t.pitchStack)-1]
+}
+
+func (t *ssmlTranslator) currentVolume() float64 {
+	if len(t.volStack) == 0 {
+		return 0.6
+	}
+	return t.volStack[len(t.volStack)-1]
+}
+
+// parseBreakTime parses a <break> element's time or strength attribute into
+// a silence duration in milliseconds.
+func parseBreakTime(el xml.StartElement) (int, error) {
+	if v, ok := attr(el, "time"); ok {
+		v = strings.TrimSpace(v)
+		switch {
+		case strings.HasSuffix(v, "ms"):
+			n, err := strconv.Atoi(strings.TrimSuffix(v, "ms"))
+			if err != nil {
+				return 0, fmt.Errorf("invalid break time %q: %w", v, err)
+			}
+			return n, nil
+		case strings.HasSuffix(v, "s"):
+			f, err := strconv.ParseFloat(strings.TrimSuffix(v, "s"), 64)
+			if err != nil {
+				return 0, fmt.Errorf("invalid break time %q: %w", v, err)
+			}
+			return int(f * 1000), nil
+		default:
+			return 0, fmt.Errorf("invalid break time %q: expected a suffix of 's' or 'ms'", v)
+		}
+	}
+
+	strength, _ := attr(el, "strength")
+	switch strength {
+	case "none":
+		return 0, nil
+	case "x-weak":
+		return 100, nil
+	case "weak":
+		return 250, nil
+	case "medium", "":
+		return 500, nil
+	case "strong":
+		return 750, nil
+	case "x-strong":
+		return 1000, nil
+	default:
+		return 0, fmt.Errorf("unsupported break strength %q", strength)
+	}
+}
+
+// parseSSMLRate parses a <prosody rate="..."> value, which may be a
+// keyword, a percentage relative to cur, or a bare words-per-minute number.
+func parseSSMLRate(v string, cur int) (int, error) {
+	if wpm, ok := ssmlRateKeywords[v]; ok {
+		return wpm, nil
+	}
+	if strings.HasSuffix(v, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid prosody rate %q: %w", v, err)
+		}
+		return int(float64(cur) * pct / 100), nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, fmt.Errorf("invalid prosody rate %q", v)
+	}
+	return n, nil
+}
+
+// parseSSMLPitch parses a <prosody pitch="..."> value, which may be a
+// keyword, a semitone offset ("+2st"), or a percentage relative to cur.
+func parseSSMLPitch(v string, cur int) (int, error) {
+	if semitones, ok := ssmlPitchKeywords[v]; ok {
+		return semitones, nil
+	}
+	if strings.HasSuffix(v, "st") {
+		n, err := strconv.Atoi(strings.TrimSuffix(v, "st"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid prosody pitch %q: %w", v, err)
+		}
+		return n, nil
+	}
+	if strings.HasSuffix(v, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid prosody pitch %q: %w", v, err)
+		}
+		return cur + int(pct/100*12), nil
+	}
+	return 0, fmt.Errorf("invalid prosody pitch %q", v)
+}
+
+// parseSSMLVolume parses a <prosody volume="..."> value, which may be a
+// keyword or a percentage relative to cur, and returns say's 0.0-1.0 scale.
+func parseSSMLVolume(v string, cur float64) (float64, error) {
+	if vol, ok := ssmlVolumeKeywords[v]; ok {
+		return vol, nil
+	}
+	if strings.HasSuffix(v, "%") {
+		pct, err := strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid prosody volume %q: %w", v, err)
+		}
+		return cur * pct / 100, nil
+	}
+	return 0, fmt.Errorf("invalid prosody volume %q", v)
+}
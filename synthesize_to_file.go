@@ -0,0 +1,255 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// audioFormat identifies a target audio container for synthesize_to_file
+// and speak_batch render jobs.
+type audioFormat string
+
+const (
+	formatAIFF audioFormat = "aiff"
+	formatWAV  audioFormat = "wav"
+	formatCAF  audioFormat = "caf"
+	formatMP3  audioFormat = "mp3"
+)
+
+// sayFileFormats maps our format names to say's --file-format values for
+// the formats say can produce directly.
+var sayFileFormats = map[audioFormat]string{
+	formatAIFF: "AIFF",
+	formatWAV:  "WAVE",
+	formatCAF:  "caff",
+}
+
+// parseAudioFormat validates a format argument, defaulting to AIFF.
+func parseAudioFormat(s string) (audioFormat, error) {
+	switch audioFormat(s) {
+	case "":
+		return formatAIFF, nil
+	case formatAIFF, formatWAV, formatCAF, formatMP3:
+		return audioFormat(s), nil
+	default:
+		return "", fmt.Errorf("unsupported format %q (expected aiff, wav, caf, or mp3)", s)
+	}
+}
+
+// synthesisCacheDir returns the directory used for content-addressed
+// render caching, creating it if necessary. It defaults to the user's
+// cache directory but can be overridden for tests or shared deployments.
+func synthesisCacheDir() (string, error) {
+	dir := os.Getenv("KJ_SPEECH_CACHE_DIR")
+	if dir == "" {
+		base, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to determine cache directory: %w", err)
+		}
+		dir = filepath.Join(base, "kj-speech-mcp")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+	}
+	return dir, nil
+}
+
+// cacheKey returns a content hash identifying a render job, used both as
+// the default output filename and to detect an already-rendered file.
+func cacheKey(text, voice string, rate float64, format audioFormat) string {
+	h := sha256.New()
+	fmt.Fprintf(h, "%s|%s|%g|%s", text, voice, rate, format)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// renderToFile synthesizes text to an audio file at outputPath in the
+// given format via the say command, transcoding through ffmpeg for mp3. If
+// skipIfExists is true and outputPath already exists, synthesis is skipped
+// and the existing file is reused; callers should only pass true when
+// outputPath is the content-addressed cache path, not a caller-supplied
+// path that may legitimately need to be overwritten.
+func renderToFile(ctx context.Context, text, voice string, rate float64, format audioFormat, outputPath string, skipIfExists bool) error {
+	if skipIfExists {
+		if _, err := os.Stat(outputPath); err == nil {
+			return nil
+		}
+	}
+
+	if format == formatMP3 {
+		return renderMP3(ctx, text, voice, rate, outputPath)
+	}
+
+	var args []string
+	if voice != "" {
+		args = append(args, "-v", voice)
+	}
+	if rate > 0 {
+		args = append(args, "-r", fmt.Sprintf("%.0f", rate))
+	}
+	args = append(args, "--file-format="+sayFileFormats[format], "-o", outputPath, text)
+
+	cmd := exec.CommandContext(ctx, sayCommand, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		errMsg := fmt.Sprintf("failed to render speech to file: %v", err)
+		if len(output) > 0 {
+			errMsg = fmt.Sprintf("%s\noutput: %s", errMsg, string(output))
+		}
+		if strings.Contains(err.Error(), "permission denied") {
+			errMsg += permissionGuidance
+		}
+		return errors.New(errMsg)
+	}
+	return nil
+}
+
+// renderMP3 renders text to a temporary AIFF file via say, then transcodes
+// it to MP3 with ffmpeg, since say has no native MP3 output.
+func renderMP3(ctx context.Context, text, voice string, rate float64, outputPath string) error {
+	ffmpegPath, err := exec.LookPath("ffmpeg")
+	if err != nil {
+		return fmt.Errorf(
+			"mp3 output requires ffmpeg, which was not found on PATH: %w\n\n"+
+				"Please install it, e.g.:\n"+
+				"1. brew install ffmpeg\n"+
+				"2. or download a build from https://ffmpeg.org/download.html\n"+
+				"3. then ensure it is on PATH for this process", err)
+	}
+
+	tmpFile, err := os.CreateTemp("", "kj-speech-*.aiff")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary audio file: %w", err)
+	}
+	tmpPath := tmpFile.Name()
+	tmpFile.Close()
+	defer os.Remove(tmpPath)
+
+	if err := renderToFile(ctx, text, voice, rate, formatAIFF, tmpPath, false); err != nil {
+		return err
+	}
+
+	cmd := exec.CommandContext(ctx, ffmpegPath, "-y", "-i", tmpPath, outputPath)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg failed to transcode to mp3: %w\noutput: %s", err, string(output))
+	}
+	return nil
+}
+
+// audioDuration shells out to afinfo to determine a rendered file's
+// duration in seconds. It returns an error rather than guessing if afinfo
+// is unavailable or its output can't be parsed.
+func audioDuration(ctx context.Context, path string) (float64, error) {
+	cmd := exec.CommandContext(ctx, "afinfo", path)
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, fmt.Errorf("failed to determine audio duration: %w", err)
+	}
+
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "estimated duration:") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 3 {
+			continue
+		}
+		if d, err := strconv.ParseFloat(fields[2], 64); err == nil {
+			return d, nil
+		}
+	}
+
+	return 0, fmt.Errorf("could not parse afinfo output for %s", path)
+}
+
+// SynthesizeToFileResult is the JSON result of the synthesize_to_file tool.
+type SynthesizeToFileResult struct {
+	Path            string  `json:"path"`
+	Format          string  `json:"format"`
+	DurationSeconds float64 `json:"duration_seconds,omitempty"`
+	Bytes           int64   `json:"bytes"`
+	Cached          bool    `json:"cached"`
+}
+
+// handleSynthesizeToFile renders text to an audio file instead of playing
+// it, reusing a previously rendered file when the content hash matches.
+func handleSynthesizeToFile(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	text, err := request.RequireString("text")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'text': %v", err)), nil
+	}
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return mcp.NewToolResultError("Parameter 'text' cannot be empty"), nil
+	}
+
+	allArgs := request.GetArguments()
+
+	var voice string
+	if v, ok := allArgs["voice"].(string); ok {
+		voice = v
+	}
+
+	var rate float64
+	if r, ok := allArgs["rate"].(float64); ok && r > 0 {
+		rate = r
+	}
+
+	formatArg, _ := allArgs["format"].(string)
+	format, err := parseAudioFormat(formatArg)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'format': %v", err)), nil
+	}
+
+	outputPath, _ := allArgs["output_path"].(string)
+	usingCachePath := outputPath == ""
+	if usingCachePath {
+		dir, err := synthesisCacheDir()
+		if err != nil {
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		outputPath = filepath.Join(dir, cacheKey(text, voice, rate, format)+"."+string(format))
+	}
+
+	_, statErr := os.Stat(outputPath)
+	cached := usingCachePath && statErr == nil
+
+	if err := renderToFile(ctx, text, voice, rate, format, outputPath, usingCachePath); err != nil {
+		return mcp.NewToolResultError(err.Error()), nil
+	}
+
+	info, err := os.Stat(outputPath)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Rendered file but failed to stat it: %v", err)), nil
+	}
+
+	duration, err := audioDuration(ctx, outputPath)
+	if err != nil {
+		duration = 0
+	}
+
+	jsonData, err := json.MarshalIndent(SynthesizeToFileResult{
+		Path:            outputPath,
+		Format:          string(format),
+		DurationSeconds: duration,
+		Bytes:           info.Size(),
+		Cached:          cached,
+	}, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to format result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Backend identifies a text-to-speech synthesis backend.
+type Backend string
+
+const (
+	// BackendSay uses the local macOS /usr/bin/say command.
+	BackendSay Backend = "say"
+	// BackendGCloud uses the Google Cloud Text-to-Speech API.
+	BackendGCloud Backend = "gcloud"
+
+	defaultBackend = BackendSay
+
+	// backendEnvVar overrides the default backend when --backend is not given.
+	backendEnvVar = "KJ_SPEECH_BACKEND"
+)
+
+// SynthesizeRequest carries the parameters needed to synthesize speech,
+// shared across all Synthesizer implementations. Not every backend honors
+// every field; unsupported fields are ignored rather than rejected.
+type SynthesizeRequest struct {
+	Text         string
+	Voice        string
+	Rate         float64
+	LanguageCode string
+	Pitch        float64
+	Volume       float64
+
+	// SSML indicates that Text is an SSML document rather than plain text.
+	// Backends that understand SSML natively (e.g. gcloud) should pass it
+	// through as-is; others are expected to have it pre-translated into
+	// their own markup before Synthesize is called.
+	SSML bool
+}
+
+// SynthesizedVoice describes a voice offered by a Synthesizer, tagged with
+// the backend that provides it so a merged voice list stays unambiguous.
+type SynthesizedVoice struct {
+	Name        string  `json:"name"`
+	Locale      string  `json:"locale"`
+	Description string  `json:"description"`
+	Gender      string  `json:"gender,omitempty"`
+	Backend     Backend `json:"backend"`
+}
+
+// Synthesizer converts text to speech audio and enumerates available voices.
+// Implementations are free to shell out to a local binary or call a cloud
+// API. handleSpeak and runPlayJob synthesize through this interface;
+// handleSpeak also checks for *gcloudSynth to decide whether SSML is passed
+// through or translated first.
+type Synthesizer interface {
+	// Synthesize renders req.Text to audio, returning the encoded audio
+	// bytes and their MIME type (e.g. "audio/aiff").
+	Synthesize(ctx context.Context, req SynthesizeRequest) (audio []byte, mime string, err error)
+
+	// ListVoices returns the voices available from this backend.
+	ListVoices(ctx context.Context) ([]SynthesizedVoice, error)
+}
+
+// configuredBackend is the default backend selected at startup via the
+// --backend flag or the KJ_SPEECH_BACKEND environment variable. Individual
+// tool calls may override it with a per-call "backend" argument.
+var configuredBackend = string(defaultBackend)
+
+// resolveDefaultBackend determines the process-wide default backend,
+// preferring an explicit flag value over the environment variable.
+func resolveDefaultBackend(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+	if envValue := os.Getenv(backendEnvVar); envValue != "" {
+		return envValue
+	}
+	return string(defaultBackend)
+}
+
+// synthesizerFor resolves a backend name to a Synthesizer, falling back to
+// the process-wide default backend when name is empty.
+func synthesizerFor(name string) (Synthesizer, error) {
+	if name == "" {
+		name = configuredBackend
+	}
+
+	switch Backend(name) {
+	case BackendSay:
+		return saySynth{}, nil
+	case BackendGCloud:
+		return getGCloudSynth(context.Background())
+	default:
+		return nil, fmt.Errorf("unknown synthesis backend %q (expected %q or %q)", name, BackendSay, BackendGCloud)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// handleTranscribe converts speech audio to text via the configured
+// Recognizer backend (or a per-call override), accepting audio either as a
+// local file path or inline base64.
+func handleTranscribe(ctx context.Context, request mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	allArgs := request.GetArguments()
+
+	var audio []byte
+	var mimeType string
+	switch {
+	case nonEmptyString(allArgs["audio_path"]) != "":
+		path := nonEmptyString(allArgs["audio_path"])
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Failed to read 'audio_path': %v", err)), nil
+		}
+		audio = data
+
+	case nonEmptyString(allArgs["audio_base64"]) != "":
+		data, err := base64.StdEncoding.DecodeString(nonEmptyString(allArgs["audio_base64"]))
+		if err != nil {
+			return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'audio_base64': %v", err)), nil
+		}
+		audio = data
+		mimeType = nonEmptyString(allArgs["mime_type"])
+
+	default:
+		return mcp.NewToolResultError("Either 'audio_path' or 'audio_base64' is required"), nil
+	}
+
+	req := TranscribeRequest{Audio: audio, MimeType: mimeType}
+	if language := nonEmptyString(allArgs["language"]); language != "" {
+		req.Language = language
+	}
+	if model := nonEmptyString(allArgs["model"]); model != "" {
+		req.Model = model
+	}
+
+	recognizer, err := recognizerFor(nonEmptyString(allArgs["backend"]))
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Invalid parameter 'backend': %v", err)), nil
+	}
+
+	result, err := recognizer.Transcribe(ctx, req)
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to transcribe audio: %v", err)), nil
+	}
+
+	jsonData, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return mcp.NewToolResultError(fmt.Sprintf("Failed to format transcription result: %v", err)), nil
+	}
+
+	return mcp.NewToolResultText(string(jsonData)), nil
+}
+
+// nonEmptyString extracts a string argument from the untyped map returned
+// by request.GetArguments(), returning "" if absent or not a string.
+func nonEmptyString(v interface{}) string {
+	s, _ := v.(string)
+	return s
+}
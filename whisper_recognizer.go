@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// whisperBinaryEnvVar overrides which executable to invoke; otherwise the
+// first of whisperBinaryCandidates found on PATH is used.
+const whisperBinaryEnvVar = "KJ_WHISPER_BINARY"
+
+// whisperModelEnvVar points at the ggml model file passed via -m. The
+// "model" argument to the transcribe tool overrides it per call.
+const whisperModelEnvVar = "KJ_WHISPER_MODEL_PATH"
+
+// whisperBinaryCandidates are executable names tried, in order, when
+// KJ_WHISPER_BINARY is not set.
+var whisperBinaryCandidates = []string{"whisper-cli", "whisper-cpp", "whisper"}
+
+// whisperRecognizer implements Recognizer by shelling out to a local
+// whisper.cpp (or whisper) binary. It is the default backend since it
+// requires no cloud credentials.
+type whisperRecognizer struct{}
+
+// whisperJSONSegment mirrors one entry of whisper.cpp's -oj output.
+type whisperJSONSegment struct {
+	Text    string `json:"text"`
+	Offsets struct {
+		From int `json:"from"` // milliseconds
+		To   int `json:"to"`   // milliseconds
+	} `json:"offsets"`
+}
+
+type whisperJSONOutput struct {
+	Transcription []whisperJSONSegment `json:"transcription"`
+}
+
+// Transcribe writes req.Audio to a temporary WAV file and runs it through
+// whisper.cpp with JSON output, translating the result into a
+// TranscriptionResult.
+func (whisperRecognizer) Transcribe(ctx context.Context, req TranscribeRequest) (*TranscriptionResult, error) {
+	binary, err := whisperBinaryPath()
+	if err != nil {
+		return nil, err
+	}
+
+	model := req.Model
+	if model == "" {
+		model = os.Getenv(whisperModelEnvVar)
+	}
+	if model == "" {
+		return nil, fmt.Errorf("no whisper model configured" + whisperGuidance())
+	}
+
+	tmpAudio, err := os.CreateTemp("", "kj-transcribe-*.wav")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary audio file: %w", err)
+	}
+	tmpPath := tmpAudio.Name()
+	defer os.Remove(tmpPath)
+	defer os.Remove(tmpPath + ".json")
+
+	if _, err := tmpAudio.Write(req.Audio); err != nil {
+		tmpAudio.Close()
+		return nil, fmt.Errorf("failed to write temporary audio file: %w", err)
+	}
+	tmpAudio.Close()
+
+	args := []string{"-m", model, "-f", tmpPath, "-oj", "-of", tmpPath}
+	if req.Language != "" {
+		args = append(args, "-l", req.Language)
+	}
+
+	cmd := exec.CommandContext(ctx, binary, args...)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("whisper transcription failed: %w\noutput: %s", err, string(output))
+	}
+
+	jsonBytes, err := os.ReadFile(tmpPath + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("whisper did not produce the expected JSON output: %w", err)
+	}
+
+	var parsed whisperJSONOutput
+	if err := json.Unmarshal(jsonBytes, &parsed); err != nil {
+		return nil, fmt.Errorf("failed to parse whisper JSON output: %w", err)
+	}
+
+	var transcriptParts []string
+	segments := make([]Segment, 0, len(parsed.Transcription))
+	for _, seg := range parsed.Transcription {
+		text := strings.TrimSpace(seg.Text)
+		transcriptParts = append(transcriptParts, text)
+		segments = append(segments, Segment{
+			Start: float64(seg.Offsets.From) / 1000,
+			End:   float64(seg.Offsets.To) / 1000,
+			Text:  text,
+		})
+	}
+
+	return &TranscriptionResult{
+		Transcript: strings.TrimSpace(strings.Join(transcriptParts, " ")),
+		Segments:   segments,
+	}, nil
+}
+
+// whisperBinaryPath resolves the whisper executable to invoke, honoring
+// KJ_WHISPER_BINARY before falling back to whisperBinaryCandidates.
+func whisperBinaryPath() (string, error) {
+	if override := os.Getenv(whisperBinaryEnvVar); override != "" {
+		path, err := exec.LookPath(override)
+		if err != nil {
+			return "", fmt.Errorf("%s=%q was not found on PATH: %w%s", whisperBinaryEnvVar, override, err, whisperGuidance())
+		}
+		return path, nil
+	}
+
+	for _, candidate := range whisperBinaryCandidates {
+		if path, err := exec.LookPath(candidate); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no whisper executable found on PATH" + whisperGuidance())
+}
+
+// whisperGuidance mirrors the server's permissionGuidance convention,
+// giving actionable setup steps when the local transcription backend
+// isn't ready to use.
+func whisperGuidance() string {
+	return fmt.Sprintf(`
+
+Please ensure:
+1. whisper.cpp is installed and one of %s is on PATH
+2. %s points at a downloaded ggml model file, e.g. ggml-base.en.bin
+3. %s can override the executable name if needed`,
+		strings.Join(whisperBinaryCandidates, ", "), whisperModelEnvVar, whisperBinaryEnvVar)
+}